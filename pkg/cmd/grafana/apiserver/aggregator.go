@@ -0,0 +1,214 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/tools/clientcmd"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	apiregistrationclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// aggregatorRegisterHookName posts an APIService for every installed group.
+// aggregatorPublishCABundleHookName keeps that CABundle current,
+// so a rotated serving cert doesn't strand kube clients with a stale one.
+const (
+	aggregatorRegisterHookName        = "grafana-apiserver-register-apiservices"
+	aggregatorPublishCABundleHookName = "grafana-apiserver-publish-ca-bundle"
+	caBundleRepublishInterval         = 10 * time.Minute
+)
+
+// AggregatorOptions controls whether grafana-apiserver registers itself as
+// an APIService against an existing kube-aggregator (Register mode), instead
+// of only being reachable standalone. This is a deliberate scope cut: the
+// originally discussed Embed mode, where grafana-apiserver runs its own
+// embedded aggregator and builds a delegate chain (aggregator -> grafana ->
+// empty) to front both Grafana's own groups and external APIService CRs
+// stored in Grafana's DB, is out of scope for this series and is not
+// implemented here. RunAPIServer still only ever delegates to
+// genericapiserver.NewEmptyDelegate().
+type AggregatorOptions struct {
+	// Enabled posts an APIService for each installed group against the
+	// aggregator reachable through RemoteKubeConfigFile, mirroring the
+	// ProxyClientCert pattern kube-aggregator's own start.go uses for
+	// servers that sit behind it.
+	Enabled bool
+
+	// RemoteKubeConfigFile points at the kube-aggregator to register with.
+	RemoteKubeConfigFile string
+	// ProxyClientCertFile/ProxyClientKeyFile are presented by the
+	// aggregator when it proxies requests through to this server.
+	ProxyClientCertFile string
+	ProxyClientKeyFile  string
+
+	// CABundleFile is a PEM-encoded CA bundle validating this server's
+	// serving certificate. It's read on every (re)publish rather than once,
+	// so a rotated file is picked up without a restart.
+	CABundleFile string
+
+	log log.Logger
+}
+
+// Validate checks AggregatorOptions when aggregation is enabled; it is a
+// no-op otherwise, matching the nil-receiver-safe pattern the rest of
+// APIServerOptions' sub-options follow.
+func (o *AggregatorOptions) Validate() error {
+	if o == nil || !o.Enabled {
+		return nil
+	}
+	if o.RemoteKubeConfigFile == "" {
+		return fmt.Errorf("aggregator: remote-kubeconfig-file is required when the aggregator is enabled")
+	}
+	if o.ProxyClientCertFile == "" || o.ProxyClientKeyFile == "" {
+		return fmt.Errorf("aggregator: proxy-client-cert-file and proxy-client-key-file are required when the aggregator is enabled")
+	}
+	if o.CABundleFile == "" {
+		return fmt.Errorf("aggregator: ca-bundle-file is required when the aggregator is enabled")
+	}
+	return nil
+}
+
+// AddFlags registers the flags needed to configure aggregation.
+func (o *AggregatorOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Enabled, "aggregator-enabled", o.Enabled,
+		"Register this server's groups as APIServices against an existing kube-aggregator.")
+	fs.StringVar(&o.RemoteKubeConfigFile, "aggregator-remote-kubeconfig-file", o.RemoteKubeConfigFile,
+		"Kubeconfig for the aggregator to register APIServices against. Required when aggregator-enabled is set.")
+	fs.StringVar(&o.ProxyClientCertFile, "aggregator-proxy-client-cert-file", o.ProxyClientCertFile,
+		"Client certificate the aggregator presents when proxying requests through to this server. Required when aggregator-enabled is set.")
+	fs.StringVar(&o.ProxyClientKeyFile, "aggregator-proxy-client-key-file", o.ProxyClientKeyFile,
+		"Private key matching aggregator-proxy-client-cert-file.")
+	fs.StringVar(&o.CABundleFile, "aggregator-ca-bundle-file", o.CABundleFile,
+		"PEM-encoded CA bundle validating this server's serving certificate, published to every registered APIService. Required when aggregator-enabled is set.")
+}
+
+func (o *AggregatorOptions) logger() log.Logger {
+	if o.log == nil {
+		o.log = log.New("grafana-apiserver.aggregator")
+	}
+	return o.log
+}
+
+func (o *AggregatorOptions) caBundle() ([]byte, error) {
+	return os.ReadFile(o.CABundleFile)
+}
+
+func (o *AggregatorOptions) apiServiceNames(builders []grafanaAPIServer.APIGroupBuilder) []string {
+	names := make([]string, 0, len(builders))
+	for _, b := range builders {
+		gv := b.GetGroupVersion()
+		names = append(names, gv.Version+"."+gv.Group)
+	}
+	return names
+}
+
+// registerAPIServicesHook returns the post-start hook that posts an
+// APIService for every installed group+version against the aggregator, so
+// it starts proxying those groups to this server. Running it as a
+// post-start hook (rather than eagerly, before PrepareRun) means the
+// APIServices only appear once this server is actually serving requests.
+func (o *AggregatorOptions) registerAPIServicesHook(builders []grafanaAPIServer.APIGroupBuilder) genericapiserver.PostStartHookFunc {
+	return func(hookCtx genericapiserver.PostStartHookContext) error {
+		client, err := o.apiregistrationClient()
+		if err != nil {
+			return err
+		}
+
+		caBundle, err := o.caBundle()
+		if err != nil {
+			return err
+		}
+
+		for _, b := range builders {
+			gv := b.GetGroupVersion()
+			svc := &apiregistrationv1.APIService{
+				ObjectMeta: metav1.ObjectMeta{Name: gv.Version + "." + gv.Group},
+				Spec: apiregistrationv1.APIServiceSpec{
+					Group:                gv.Group,
+					Version:              gv.Version,
+					CABundle:             caBundle,
+					GroupPriorityMinimum: 1000,
+					VersionPriority:      15,
+				},
+			}
+			if _, err := client.ApiregistrationV1().APIServices().Create(hookCtx.Context, svc, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// publishCABundleHook returns the post-start hook that keeps the CABundle on
+// every APIService registered above in sync with CABundleFile, so a cert
+// rotation propagates to the aggregator without requiring a restart. It
+// re-reads the file and re-patches on a timer until the server shuts down.
+func (o *AggregatorOptions) publishCABundleHook(builders []grafanaAPIServer.APIGroupBuilder) genericapiserver.PostStartHookFunc {
+	return func(hookCtx genericapiserver.PostStartHookContext) error {
+		client, err := o.apiregistrationClient()
+		if err != nil {
+			return err
+		}
+		names := o.apiServiceNames(builders)
+
+		go func() {
+			ticker := time.NewTicker(caBundleRepublishInterval)
+			defer ticker.Stop()
+
+			for {
+				if err := o.publishCABundle(hookCtx.Context, client, names); err != nil {
+					o.logger().Error("failed to publish CA bundle to aggregator", "error", err)
+				}
+
+				select {
+				case <-hookCtx.Context.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+
+		return nil
+	}
+}
+
+func (o *AggregatorOptions) publishCABundle(ctx context.Context, client *apiregistrationclient.Clientset, names []string) error {
+	caBundle, err := o.caBundle()
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"spec": map[string]any{"caBundle": caBundle},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := client.ApiregistrationV1().APIServices().Patch(
+			ctx, name, types.MergePatchType, patch, metav1.PatchOptions{},
+		); err != nil {
+			return fmt.Errorf("patching APIService %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (o *AggregatorOptions) apiregistrationClient() (*apiregistrationclient.Clientset, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", o.RemoteKubeConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return apiregistrationclient.NewForConfig(restConfig)
+}
@@ -0,0 +1,39 @@
+package apiserver
+
+import (
+	"k8s.io/apiserver/pkg/audit"
+
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// extraAuditBackends returns the Grafana-native audit.Backends to union
+// with whatever RecommendedOptions.Audit's policy file, log path, or
+// webhook flags already configured: a log backend always (so audit events
+// show up alongside the rest of Grafana's logs) and, when AuditToGrafanaDB
+// is set, a SQL backend writing to that table.
+func (o *APIServerOptions) extraAuditBackends() ([]audit.Backend, error) {
+	backends := []audit.Backend{grafanaAPIServer.NewGrafanaLogAuditBackend()}
+
+	if o.AuditToGrafanaDB != "" {
+		sqlBackend, err := grafanaAPIServer.NewSQLAuditBackend(o.AuditDB, o.AuditToGrafanaDB)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, sqlBackend)
+	}
+
+	return backends, nil
+}
+
+// combineAuditBackends prepends existing to extra, unless existing is nil.
+// RecommendedOptions.Audit.ApplyTo leaves config.Config.AuditBackend (the
+// existing argument here) as a nil interface whenever no policy file, log
+// path, or webhook was configured, which is the common case. auditunion.New
+// doesn't nil-check its backends, so passing a nil one through panics the
+// moment the union is Run or handed an event.
+func combineAuditBackends(existing audit.Backend, extra []audit.Backend) []audit.Backend {
+	if existing == nil {
+		return extra
+	}
+	return append([]audit.Backend{existing}, extra...)
+}
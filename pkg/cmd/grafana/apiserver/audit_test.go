@@ -0,0 +1,38 @@
+package apiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+)
+
+type fakeAuditBackend struct {
+	name string
+}
+
+func (f *fakeAuditBackend) ProcessEvents(...*auditinternal.Event) {}
+func (f *fakeAuditBackend) Run(stopCh <-chan struct{}) error       { return nil }
+func (f *fakeAuditBackend) Shutdown()                              {}
+func (f *fakeAuditBackend) String() string                         { return f.name }
+
+func TestCombineAuditBackends(t *testing.T) {
+	extra := []audit.Backend{&fakeAuditBackend{name: "log"}}
+
+	t.Run("nil existing backend is dropped, not passed through", func(t *testing.T) {
+		backends := combineAuditBackends(nil, extra)
+		require.Len(t, backends, 1)
+		for _, b := range backends {
+			require.NotNil(t, b)
+		}
+	})
+
+	t.Run("non-nil existing backend is kept alongside the extras", func(t *testing.T) {
+		existing := &fakeAuditBackend{name: "webhook"}
+		backends := combineAuditBackends(existing, extra)
+		require.Len(t, backends, 2)
+		require.Equal(t, "webhook", backends[0].String())
+		require.Equal(t, "log", backends[1].String())
+	})
+}
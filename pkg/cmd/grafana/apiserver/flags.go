@@ -0,0 +1,38 @@
+package apiserver
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// String implements pflag.Value so StorageType can be bound directly via
+// fs.Var instead of a plain fs.StringVar plus hand-rolled validation.
+func (s *StorageType) String() string { return string(*s) }
+
+func (s *StorageType) Set(value string) error {
+	switch StorageType(value) {
+	case StorageTypeUnistore, StorageTypeEtcd, StorageTypeSQL:
+		*s = StorageType(value)
+		return nil
+	default:
+		return fmt.Errorf("unknown storage-backend %q: must be one of unistore, etcd, sql", value)
+	}
+}
+
+func (s *StorageType) Type() string { return "string" }
+
+// AddFlags registers the flags APIServerOptions understands, mirroring how
+// RecommendedOptions' own sub-options each expose an AddFlags.
+func (o *APIServerOptions) AddFlags(fs *pflag.FlagSet) {
+	o.RecommendedOptions.AddFlags(fs)
+
+	fs.Var(&o.StorageType, "storage-backend",
+		"Storage backend for the installed groups: unistore, etcd, or sql (not yet implemented).")
+
+	o.Aggregator.AddFlags(fs)
+
+	fs.StringVar(&o.AuditToGrafanaDB, "audit-to-grafana-db", o.AuditToGrafanaDB,
+		"SQL table (expected to already exist, e.g. api_server_audit) that audit events are additionally written to. "+
+			"AuditDB itself isn't a flag; the caller must still wire it in when this is set.")
+}
@@ -4,21 +4,30 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"path"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	auditunion "k8s.io/apiserver/pkg/audit/union"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/apiserver/pkg/server/options"
+	serverstorage "k8s.io/apiserver/pkg/server/storage"
 	"k8s.io/client-go/tools/clientcmd"
 	netutils "k8s.io/utils/net"
 
-	"github.com/grafana/grafana/pkg/registry/apis/example"
-	"github.com/grafana/grafana/pkg/registry/apis/featuretoggle"
-	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
 	"github.com/grafana/grafana/pkg/services/grafana-apiserver/utils"
 	"github.com/grafana/grafana/pkg/setting"
+
+	// Side-effect imports: each of these registers one or more API groups
+	// with grafanaAPIServer.DefaultRegistry from an init(). Compile in an
+	// out-of-tree group by adding its import here (or behind a build tag).
+	_ "github.com/grafana/grafana/pkg/registry/apis/example"
+	_ "github.com/grafana/grafana/pkg/registry/apis/featuretoggle"
+	_ "github.com/grafana/grafana/pkg/server"
 )
 
 const (
@@ -26,42 +35,81 @@ const (
 	dataPath              = "data/grafana-apiserver" // same as grafana core
 )
 
+// StorageType selects which backend InstallAPIs persists resources to.
+type StorageType string
+
+const (
+	// StorageTypeUnistore keeps every group on the in-process unistore, as
+	// today. This is the default.
+	StorageTypeUnistore StorageType = "unistore"
+	// StorageTypeEtcd stores resources in a real etcd cluster, via the
+	// RecommendedOptions.Etcd machinery kube-apiserver itself uses.
+	StorageTypeEtcd StorageType = "etcd"
+	// StorageTypeSQL names Grafana's own SQL store. Validate and Config
+	// reject it: no storage adapter over that store exists.
+	StorageTypeSQL StorageType = "sql"
+)
+
 // APIServerOptions contains the state for the apiserver
 type APIServerOptions struct {
 	builders           []grafanaAPIServer.APIGroupBuilder
 	RecommendedOptions *options.RecommendedOptions
 	AlternateDNS       []string
 
+	// AccessControl backs the authorizer that replaces the disabled
+	// aggregated SubjectAccessReview authorization.
+	AccessControl accesscontrol.AccessControl
+
+	// Aggregator, when Enabled, registers this server's groups as
+	// APIServices against an existing kube-aggregator instead of leaving
+	// it reachable only standalone.
+	Aggregator *AggregatorOptions
+
+	// AuditToGrafanaDB, when non-empty, names a SQL table (expected to
+	// already exist, e.g. api_server_audit) that audit events are written
+	// to in addition to whatever RecommendedOptions.Audit's policy file,
+	// log path, or webhook flags already configure. AuditDB must be set
+	// when this is.
+	AuditToGrafanaDB string
+	AuditDB          db.DB
+
+	// StorageType selects unistore, etcd, or sql as the storage backend for
+	// the installed groups. Flag: --storage-backend.
+	StorageType StorageType
+	// storageFactory is non-nil once Config() has resolved StorageType into
+	// a concrete per-resource encoding, and is consulted by ModifiedApplyTo.
+	storageFactory serverstorage.StorageFactory
+
+	// DisableOpenAPIV3 skips building the /openapi/v3 document, for
+	// environments that only want the legacy v2 one.
+	DisableOpenAPIV3 bool
+
 	StdOut io.Writer
 	StdErr io.Writer
 }
 
 func newAPIServerOptions(out, errOut io.Writer) *APIServerOptions {
 	return &APIServerOptions{
-		StdOut: out,
-		StdErr: errOut,
+		StorageType: StorageTypeUnistore,
+		Aggregator:  &AggregatorOptions{},
+		StdOut:      out,
+		StdErr:      errOut,
 	}
 }
 
 func (o *APIServerOptions) loadAPIGroupBuilders(args []string) error {
+	deps := grafanaAPIServer.BuilderDeps{
+		FeatureToggles: featuremgmt.WithFeatureManager(setting.FeatureMgmtSettings{}, nil), // none... for now
+		Cfg:            setting.NewCfg(),
+	}
+
 	o.builders = []grafanaAPIServer.APIGroupBuilder{}
 	for _, g := range args {
-		switch g {
-		// No dependencies for testing
-		case "example.grafana.app":
-			o.builders = append(o.builders, example.NewTestingAPIBuilder())
-		case "featuretoggle.grafana.app":
-			features := featuremgmt.WithFeatureManager(setting.FeatureMgmtSettings{}, nil) // none... for now
-			o.builders = append(o.builders, featuretoggle.NewFeatureFlagAPIBuilder(features))
-		case "testdata.datasource.grafana.app":
-			ds, err := server.InitializeDataSourceAPIServer(g)
-			if err != nil {
-				return err
-			}
-			o.builders = append(o.builders, ds)
-		default:
-			return fmt.Errorf("unknown group: %s", g)
+		builder, err := grafanaAPIServer.DefaultRegistry.Build(g, deps)
+		if err != nil {
+			return err
 		}
+		o.builders = append(o.builders, builder)
 	}
 
 	if len(o.builders) < 1 {
@@ -77,10 +125,38 @@ func (o *APIServerOptions) loadAPIGroupBuilders(args []string) error {
 	return nil
 }
 
+// buildStorageFactory builds a per-resource-encoding StorageFactory against
+// RecommendedOptions.Etcd.StorageConfig (prefixed under
+// defaultEtcdPathPrefix) and gives every builder that opted in via
+// grafanaAPIServer.APIGroupBuilderWithStorage a chance to declare its own
+// media type before it's handed to RecommendedOptions.Etcd.ApplyWithStorageFactoryTo.
+func (o *APIServerOptions) buildStorageFactory() (serverstorage.StorageFactory, error) {
+	storageFactory := serverstorage.NewDefaultStorageFactory(
+		o.RecommendedOptions.Etcd.StorageConfig,
+		"application/json",
+		grafanaAPIServer.Codecs,
+		serverstorage.NewDefaultResourceEncodingConfig(grafanaAPIServer.Scheme),
+		serverstorage.NewResourceConfig(),
+		nil,
+	)
+
+	for _, b := range o.builders {
+		if withStorage, ok := b.(grafanaAPIServer.APIGroupBuilderWithStorage); ok {
+			withStorage.SetStorageConfig(storageFactory)
+		}
+	}
+
+	return storageFactory, nil
+}
+
 // A copy of ApplyTo in recommended.go, but for >= 0.28, server pkg in apiserver does a bit extra causing
 // a panic when CoreAPI is set to nil
 func (o *APIServerOptions) ModifiedApplyTo(config *genericapiserver.RecommendedConfig) error {
-	if err := o.RecommendedOptions.Etcd.ApplyTo(&config.Config); err != nil {
+	if o.storageFactory != nil {
+		if err := o.RecommendedOptions.Etcd.ApplyWithStorageFactoryTo(o.storageFactory, &config.Config); err != nil {
+			return err
+		}
+	} else if err := o.RecommendedOptions.Etcd.ApplyTo(&config.Config); err != nil {
 		return err
 	}
 	if err := o.RecommendedOptions.EgressSelector.ApplyTo(&config.Config); err != nil {
@@ -101,6 +177,13 @@ func (o *APIServerOptions) ModifiedApplyTo(config *genericapiserver.RecommendedC
 	if err := o.RecommendedOptions.Audit.ApplyTo(&config.Config); err != nil {
 		return err
 	}
+	extraAudit, auditErr := o.extraAuditBackends()
+	if auditErr != nil {
+		return auditErr
+	}
+	if backends := combineAuditBackends(config.Config.AuditBackend, extraAudit); len(backends) > 0 {
+		config.Config.AuditBackend = auditunion.New(backends...)
+	}
 
 	// TODO: determine whether we need flow control (API priority and fairness)
 	// We can't assume that a shared informers config was provided in standalone mode and will need a guard
@@ -134,13 +217,32 @@ func (o *APIServerOptions) Config() (*genericapiserver.RecommendedConfig, error)
 
 	o.RecommendedOptions.Authentication.RemoteKubeConfigFileOptional = true
 
-	// TODO: determine authorization, currently insecure because Authorization provided by recommended options doesn't work
-	// reason: an aggregated server won't be able to post subjectaccessreviews (Grafana doesn't have this kind)
-	// exact error: the server could not find the requested resource (post subjectaccessreviews.authorization.k8s.io)
+	// Authorization provided by RecommendedOptions doesn't work here: an
+	// aggregated server won't be able to post subjectaccessreviews (Grafana
+	// doesn't have this kind; exact error: "the server could not find the
+	// requested resource (post subjectaccessreviews.authorization.k8s.io)").
+	// Skip it and wire in a Grafana-native authorizer below instead.
 	o.RecommendedOptions.Authorization = nil
 
 	o.RecommendedOptions.Admission = nil
-	o.RecommendedOptions.Etcd = nil
+
+	switch o.StorageType {
+	case StorageTypeEtcd:
+		factory, err := o.buildStorageFactory()
+		if err != nil {
+			return nil, err
+		}
+		o.storageFactory = factory
+	case StorageTypeSQL:
+		// Rejected in Validate, which runs before Config: there's no
+		// storage.Interface adapter over Grafana's SQL store yet, so
+		// silently falling back to unistore would leave an operator who
+		// explicitly asked for persistent sql storage believing their data
+		// survives a restart when it doesn't.
+		return nil, fmt.Errorf("storage-backend=sql is not implemented yet")
+	default:
+		o.RecommendedOptions.Etcd = nil
+	}
 
 	if o.RecommendedOptions.CoreAPI.CoreAPIKubeconfigPath == "" {
 		o.RecommendedOptions.CoreAPI = nil
@@ -161,8 +263,23 @@ func (o *APIServerOptions) Config() (*genericapiserver.RecommendedConfig, error)
 	serverConfig.DisabledPostStartHooks = serverConfig.DisabledPostStartHooks.Insert("generic-apiserver-start-informers")
 	serverConfig.DisabledPostStartHooks = serverConfig.DisabledPostStartHooks.Insert("priority-and-fairness-config-consumer")
 
+	// Delegate to Grafana's own accesscontrol service instead of the
+	// disabled aggregated authorizer above, letting each builder
+	// contribute its own resource->action map (see APIGroupAuthorizer).
+	serverConfig.Authorization.Authorizer = grafanaAPIServer.NewGrafanaAuthorizer(o.AccessControl, o.builders)
+
+	// Authorize can't hand a modified context back to its caller, so
+	// propagating the authenticated user into Grafana's own identity (for
+	// downstream storage layers to enforce per-org isolation with) has to
+	// happen as its own filter, wrapped directly around the REST dispatcher
+	// rather than around the whole chain DefaultBuildHandlerChain builds.
+	defaultBuildHandlerChain := serverConfig.BuildHandlerChainFunc
+	serverConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) http.Handler {
+		return defaultBuildHandlerChain(grafanaAPIServer.WithGrafanaIdentity(apiHandler), c)
+	}
+
 	// Add OpenAPI specs for each group+version
-	err := grafanaAPIServer.SetupConfig(serverConfig, o.builders)
+	err := grafanaAPIServer.SetupConfig(serverConfig, o.builders, !o.DisableOpenAPIV3)
 	return serverConfig, err
 }
 
@@ -172,6 +289,12 @@ func (o *APIServerOptions) Config() (*genericapiserver.RecommendedConfig, error)
 func (o *APIServerOptions) Validate(args []string) error {
 	errors := []error{}
 	errors = append(errors, o.RecommendedOptions.Validate()...)
+	if err := o.Aggregator.Validate(); err != nil {
+		errors = append(errors, err)
+	}
+	if o.StorageType == StorageTypeSQL {
+		errors = append(errors, fmt.Errorf("storage-backend=sql is not implemented yet"))
+	}
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -203,5 +326,21 @@ func (o *APIServerOptions) RunAPIServer(config *genericapiserver.RecommendedConf
 		return err
 	}
 
+	if o.Aggregator != nil && o.Aggregator.Enabled {
+		// Each APIService carries a CABundle read from CABundleFile, which
+		// is how kube clients validate this server's TLS through the
+		// aggregator. A second hook keeps that CABundle in sync with the
+		// file so a cert rotation propagates without a restart. Post-start
+		// hooks run concurrently, so its first publish can run before the
+		// APIServices exist; that failure is logged and retried on the next
+		// tick.
+		if err := server.AddPostStartHook(aggregatorRegisterHookName, o.Aggregator.registerAPIServicesHook(o.builders)); err != nil {
+			return err
+		}
+		if err := server.AddPostStartHook(aggregatorPublishCABundleHookName, o.Aggregator.publishCABundleHook(o.builders)); err != nil {
+			return err
+		}
+	}
+
 	return server.PrepareRun().Run(stopCh)
 }
@@ -0,0 +1,16 @@
+package example
+
+import (
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// init registers this group with the default builder registry so that
+// loadAPIGroupBuilders never needs to know example.grafana.app exists.
+func init() {
+	grafanaAPIServer.DefaultRegistry.Register("example.grafana.app",
+		func(_ grafanaAPIServer.BuilderDeps) (grafanaAPIServer.APIGroupBuilder, error) {
+			// No dependencies for testing
+			return NewTestingAPIBuilder(), nil
+		},
+	)
+}
@@ -0,0 +1,38 @@
+package featuretoggle
+
+import (
+	"context"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// authorizedAPIBuilder wraps NewFeatureFlagAPIBuilder with a GetAuthorizer so
+// it satisfies grafanaAPIServer.APIGroupAuthorizer, giving this group its own
+// resource->action map instead of falling through to the default
+// accesscontrol evaluator.
+type authorizedAPIBuilder struct {
+	grafanaAPIServer.APIGroupBuilder
+}
+
+// GetAuthorizer allows reading feature flag values unconditionally (they
+// aren't sensitive, and several UI features poll them on every page load)
+// while denying every write verb, since there is no supported way to change
+// a flag through this API yet.
+func (b *authorizedAPIBuilder) GetAuthorizer() authorizer.Authorizer {
+	return authorizerFunc(func(_ context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		switch a.GetVerb() {
+		case "get", "list", "watch":
+			return authorizer.DecisionAllow, "", nil
+		default:
+			return authorizer.DecisionDeny, "feature flags cannot be modified through this API", nil
+		}
+	})
+}
+
+type authorizerFunc func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error)
+
+func (f authorizerFunc) Authorize(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+	return f(ctx, a)
+}
@@ -0,0 +1,34 @@
+package featuretoggle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestAuthorizedAPIBuilder_GetAuthorizer(t *testing.T) {
+	b := &authorizedAPIBuilder{}
+	auth := b.GetAuthorizer()
+
+	cases := []struct {
+		verb     string
+		expected authorizer.Decision
+	}{
+		{verb: "get", expected: authorizer.DecisionAllow},
+		{verb: "list", expected: authorizer.DecisionAllow},
+		{verb: "watch", expected: authorizer.DecisionAllow},
+		{verb: "create", expected: authorizer.DecisionDeny},
+		{verb: "update", expected: authorizer.DecisionDeny},
+		{verb: "delete", expected: authorizer.DecisionDeny},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.verb, func(t *testing.T) {
+			decision, _, err := auth.Authorize(context.Background(), authorizer.AttributesRecord{Verb: tc.verb})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, decision)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package featuretoggle
+
+import (
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// init registers this group with the default builder registry so that
+// loadAPIGroupBuilders never needs to know featuretoggle.grafana.app exists.
+func init() {
+	grafanaAPIServer.DefaultRegistry.Register("featuretoggle.grafana.app",
+		func(deps grafanaAPIServer.BuilderDeps) (grafanaAPIServer.APIGroupBuilder, error) {
+			return &authorizedAPIBuilder{NewFeatureFlagAPIBuilder(deps.FeatureToggles)}, nil
+		},
+	)
+}
@@ -0,0 +1,17 @@
+package server
+
+import (
+	grafanaAPIServer "github.com/grafana/grafana/pkg/services/grafana-apiserver"
+)
+
+// init registers the testdata datasource group with the default builder
+// registry. It lives in pkg/server, rather than under pkg/registry/apis,
+// because InitializeDataSourceAPIServer needs the full dependency
+// injection wiring this package already owns.
+func init() {
+	grafanaAPIServer.DefaultRegistry.Register("testdata.datasource.grafana.app",
+		func(_ grafanaAPIServer.BuilderDeps) (grafanaAPIServer.APIGroupBuilder, error) {
+			return InitializeDataSourceAPIServer("testdata.datasource.grafana.app")
+		},
+	)
+}
@@ -0,0 +1,47 @@
+package grafanaapiserver
+
+import (
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// logAuditBackend is an audit.Backend that forwards every event to
+// Grafana's own structured logger, so audit events show up alongside the
+// rest of Grafana's logs instead of only in the separate log file or
+// webhook that RecommendedOptions.Audit's flags configure.
+type logAuditBackend struct {
+	log log.Logger
+}
+
+// NewGrafanaLogAuditBackend returns an audit.Backend that writes events
+// through Grafana's log.Logger pipeline.
+func NewGrafanaLogAuditBackend() audit.Backend {
+	return &logAuditBackend{log: log.New("grafana-apiserver.audit")}
+}
+
+func (b *logAuditBackend) ProcessEvents(events ...*auditinternal.Event) {
+	for _, ev := range events {
+		// ObjectRef is nil for non-resource requests (health checks,
+		// discovery, OpenAPI, version, ...).
+		var objRef auditinternal.ObjectReference
+		if ev.ObjectRef != nil {
+			objRef = *ev.ObjectRef
+		}
+		b.log.Info("api audit event",
+			"user", ev.User.Username,
+			"verb", ev.Verb,
+			"group", objRef.APIGroup,
+			"resource", objRef.Resource,
+			"namespace", objRef.Namespace,
+			"name", objRef.Name,
+			"stage", ev.Stage,
+			"responseStatus", ev.ResponseStatus,
+		)
+	}
+}
+
+func (b *logAuditBackend) Run(stopCh <-chan struct{}) error { return nil }
+func (b *logAuditBackend) Shutdown()                        {}
+func (b *logAuditBackend) String() string                   { return "grafana-log" }
@@ -0,0 +1,19 @@
+package grafanaapiserver
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+func TestLogAuditBackend_ProcessEvents_NilObjectRef(t *testing.T) {
+	backend := NewGrafanaLogAuditBackend()
+
+	// ObjectRef is nil for non-resource requests (health checks, discovery,
+	// OpenAPI, version, ...); this must not panic.
+	backend.ProcessEvents(&auditinternal.Event{
+		User: authnv1.UserInfo{Username: "test-user"},
+		Verb: "get",
+	})
+}
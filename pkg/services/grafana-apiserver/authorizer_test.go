@@ -0,0 +1,113 @@
+package grafanaapiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	common "k8s.io/kube-openapi/pkg/common"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+)
+
+// fakeAPIGroupBuilder is the minimal APIGroupBuilder stub the tests in this
+// package need; it only fills in the methods NewGrafanaAuthorizer actually
+// calls.
+type fakeAPIGroupBuilder struct {
+	group string
+}
+
+func (f fakeAPIGroupBuilder) InstallSchema(*runtime.Scheme) error { return nil }
+func (f fakeAPIGroupBuilder) GetGroupVersion() schema.GroupVersion {
+	return schema.GroupVersion{Group: f.group, Version: "v0alpha1"}
+}
+func (f fakeAPIGroupBuilder) GetOpenAPIDefinitions() common.GetOpenAPIDefinitions {
+	return func(common.ReferenceCallback) map[string]common.OpenAPIDefinition { return nil }
+}
+
+type fakeAPIGroupAuthorizer struct {
+	fakeAPIGroupBuilder
+	authorizer authorizer.Authorizer
+}
+
+func (f *fakeAPIGroupAuthorizer) GetAuthorizer() authorizer.Authorizer { return f.authorizer }
+
+type fakeAccessControl struct {
+	allow bool
+	err   error
+}
+
+func (f *fakeAccessControl) Evaluate(_ context.Context, _ identity.Requester, _ accesscontrol.Evaluator) (bool, error) {
+	return f.allow, f.err
+}
+
+type fakeGroupAuthorizer struct {
+	decision authorizer.Decision
+}
+
+func (f *fakeGroupAuthorizer) Authorize(context.Context, authorizer.Attributes) (authorizer.Decision, string, error) {
+	return f.decision, "delegated", nil
+}
+
+func attributesFor(group, resource, verb string) authorizer.Attributes {
+	return authorizer.AttributesRecord{
+		User:            &user.DefaultInfo{Name: "test-user"},
+		APIGroup:        group,
+		Resource:        resource,
+		Verb:            verb,
+		ResourceRequest: true,
+	}
+}
+
+func TestDelegatingAuthorizer_NoUser(t *testing.T) {
+	d := NewGrafanaAuthorizer(&fakeAccessControl{allow: true}, nil)
+
+	attrs := authorizer.AttributesRecord{APIGroup: "example.grafana.app", Resource: "things", Verb: "get"}
+	decision, reason, err := d.Authorize(context.Background(), attrs)
+
+	require.NoError(t, err)
+	require.Equal(t, authorizer.DecisionDeny, decision)
+	require.Equal(t, "no grafana identity on request", reason)
+}
+
+func TestDelegatingAuthorizer_DefaultEvaluator(t *testing.T) {
+	cases := []struct {
+		name     string
+		allow    bool
+		expected authorizer.Decision
+	}{
+		{name: "allowed by accesscontrol", allow: true, expected: authorizer.DecisionAllow},
+		{name: "denied by accesscontrol", allow: false, expected: authorizer.DecisionDeny},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewGrafanaAuthorizer(&fakeAccessControl{allow: tc.allow}, nil)
+
+			decision, _, err := d.Authorize(context.Background(), attributesFor("example.grafana.app", "things", "get"))
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, decision)
+		})
+	}
+}
+
+func TestDelegatingAuthorizer_PerGroupDelegate(t *testing.T) {
+	builder := &fakeAPIGroupAuthorizer{
+		fakeAPIGroupBuilder: fakeAPIGroupBuilder{group: "featuretoggle.grafana.app"},
+		authorizer:          &fakeGroupAuthorizer{decision: authorizer.DecisionAllow},
+	}
+
+	d := NewGrafanaAuthorizer(&fakeAccessControl{allow: false}, []APIGroupBuilder{builder})
+
+	decision, reason, err := d.Authorize(context.Background(), attributesFor("featuretoggle.grafana.app", "flags", "list"))
+
+	require.NoError(t, err)
+	require.Equal(t, authorizer.DecisionAllow, decision)
+	require.Equal(t, "delegated", reason)
+}
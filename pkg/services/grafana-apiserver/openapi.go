@@ -0,0 +1,43 @@
+package grafanaapiserver
+
+import (
+	common "k8s.io/kube-openapi/pkg/common"
+	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+)
+
+// mergeOpenAPIDefinitions combines the per-group definitions contributed by
+// each builder into the single callback genericapiserver.DefaultOpenAPIConfig
+// (and its v3 counterpart) expect.
+func mergeOpenAPIDefinitions(builders []APIGroupBuilder) common.GetOpenAPIDefinitions {
+	return func(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
+		defs := map[string]common.OpenAPIDefinition{}
+		for _, b := range builders {
+			for k, v := range b.GetOpenAPIDefinitions()(ref) {
+				defs[k] = v
+			}
+		}
+		return defs
+	}
+}
+
+// SetupConfig attaches the OpenAPI v2 (and, unless disabled, v3) config to
+// serverConfig, built from the definitions each of builders contributes.
+// enableOpenAPIV3 mirrors kube-apiserver's OpenAPIV3 feature gate: operators
+// who only care about the legacy v2 document can skip generating v3.
+func SetupConfig(serverConfig *genericapiserver.RecommendedConfig, builders []APIGroupBuilder, enableOpenAPIV3 bool) error {
+	defsGetter := mergeOpenAPIDefinitions(builders)
+	namer := openapinamer.NewDefinitionNamer(Scheme)
+
+	serverConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(defsGetter, namer)
+	serverConfig.OpenAPIConfig.Info.Title = "Grafana API Server"
+	serverConfig.OpenAPIConfig.Info.Version = "v0.1"
+
+	if enableOpenAPIV3 {
+		serverConfig.OpenAPIV3Config = genericapiserver.DefaultOpenAPIV3Config(defsGetter, namer)
+		serverConfig.OpenAPIV3Config.Info.Title = "Grafana API Server"
+		serverConfig.OpenAPIV3Config.Info.Version = "v0.1"
+	}
+
+	return nil
+}
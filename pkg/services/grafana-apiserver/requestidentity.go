@@ -0,0 +1,37 @@
+package grafanaapiserver
+
+import (
+	"net/http"
+
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+)
+
+// WithGrafanaIdentity maps the k8s user.Info that genericapiserver's
+// authentication filter already put on the request context into Grafana's
+// own identity.Requester, so storage layers downstream can enforce per-org
+// isolation the same way they do for ordinary HTTP requests.
+//
+// It must be installed inside the handler chain, after authentication (and,
+// for our purposes, after authorization — see delegatingAuthorizer.Authorize
+// for why that filter can't do this itself) and before the REST dispatcher.
+// pkg/cmd/grafana/apiserver/server.go wires it in via
+// RecommendedConfig.BuildHandlerChainFunc,
+// wrapping the innermost apiHandler rather than the chain genericapiserver
+// builds around it.
+func WithGrafanaIdentity(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		info, ok := genericapirequest.UserFrom(ctx)
+		if ok && info != nil {
+			if requester, err := appcontext.UserFromK8sUserInfo(info); err == nil {
+				ctx = appcontext.WithUser(ctx, requester)
+				req = req.WithContext(ctx)
+			}
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
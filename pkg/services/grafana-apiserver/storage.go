@@ -0,0 +1,19 @@
+package grafanaapiserver
+
+import (
+	serverstorage "k8s.io/apiserver/pkg/server/storage"
+)
+
+// APIGroupBuilderWithStorage is implemented by an APIGroupBuilder that wants
+// a say in its own storage encoding (media type, versions) when running
+// against the etcd storage backend, instead of taking the
+// apiserver-wide default. Builders that don't implement it are unaffected
+// by the choice of --storage-backend.
+type APIGroupBuilderWithStorage interface {
+	APIGroupBuilder
+
+	// SetStorageConfig is called once, after the storage factory has been
+	// built, so the builder's REST storage can look up its own codec and
+	// prefix instead of assuming the apiserver-wide default.
+	SetStorageConfig(factory serverstorage.StorageFactory)
+}